@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/synce"
 
@@ -19,6 +22,11 @@ import (
 	ptpv1 "github.com/k8snetworkplumbingwg/ptp-operator/api/v1"
 )
 
+// DEFAULT_NODE_PROFILE_PATH is the file read on SIGHUP when no explicit
+// reload path was given to WatchForReload. It matches where the operator
+// mounts the node's PtpConfig ConfigMap inside the daemon container.
+const DEFAULT_NODE_PROFILE_PATH = "/etc/linuxptp/ptpconfig/node-profile.json"
+
 // LinuxPTPUpdate controls whether to update linuxPTP conf
 // and contains linuxPTP conf to be updated. It's rendered
 // and passed to linuxptp instance by daemon.
@@ -27,6 +35,20 @@ type LinuxPTPConfUpdate struct {
 	NodeProfiles           []ptpv1.PtpProfile
 	appliedNodeProfileJson []byte
 	defaultPTP4lConfig     []byte
+
+	// profileSourcePath is the file re-read on SIGHUP. It is set by
+	// WatchForReload and left empty when live reload isn't in use.
+	profileSourcePath string
+	// lastGoodNodeProfileJson holds the profile applied before the most
+	// recent reload, so a failed reload can be rolled back with
+	// RollbackConfig instead of leaving ptp4l/phc2sys running with a
+	// half-applied config.
+	lastGoodNodeProfileJson []byte
+
+	// mu guards appliedNodeProfileJson, NodeProfiles and
+	// lastGoodNodeProfileJson, which UpdateConfig and the WatchForReload
+	// goroutine can now both mutate concurrently.
+	mu sync.Mutex
 }
 
 type ptp4lConfSection struct {
@@ -39,7 +61,8 @@ type ptp4lConf struct {
 	mapping          []string
 	profile_name     string
 	clock_type       event.ClockType
-	gnss_serial_port string // gnss serial port
+	gnss_serial_port string            // gnss serial port
+	gnss_source      event.EventSource // source the [nmea] section's ts2phc.master resolves to: GNSS or PPS
 }
 
 func NewLinuxPTPConfUpdate() (*LinuxPTPConfUpdate, error) {
@@ -60,13 +83,16 @@ func NewLinuxPTPConfUpdate() (*LinuxPTPConfUpdate, error) {
 }
 
 func (l *LinuxPTPConfUpdate) UpdateConfig(nodeProfilesJson []byte) error {
+	l.mu.Lock()
 	if string(l.appliedNodeProfileJson) == string(nodeProfilesJson) {
+		l.mu.Unlock()
 		return nil
 	}
 	if nodeProfiles, ok := tryToLoadConfig(nodeProfilesJson); ok {
 		glog.Info("load profiles")
 		l.appliedNodeProfileJson = nodeProfilesJson
 		l.NodeProfiles = nodeProfiles
+		l.mu.Unlock()
 		l.UpdateCh <- true
 
 		return nil
@@ -77,20 +103,169 @@ func (l *LinuxPTPConfUpdate) UpdateConfig(nodeProfilesJson []byte) error {
 		// '{"name":null,"interface":null}'
 		if nodeProfiles[0].Name == nil || nodeProfiles[0].Interface == nil {
 			glog.Infof("Skip no profile %+v", nodeProfiles[0])
+			l.mu.Unlock()
 			return nil
 		}
 
 		glog.Info("load profiles using old method")
 		l.appliedNodeProfileJson = nodeProfilesJson
 		l.NodeProfiles = nodeProfiles
+		l.mu.Unlock()
 		l.UpdateCh <- true
 
 		return nil
 	}
 
+	l.mu.Unlock()
 	return fmt.Errorf("unable to load profile config")
 }
 
+// WatchForReload installs a SIGHUP handler that re-reads node profiles
+// from profilePath (or DEFAULT_NODE_PROFILE_PATH, the currently-mounted
+// profile ConfigMap file, when profilePath is empty) and applies them via
+// ReloadConfig without requiring a daemon restart. It blocks until stopCh
+// is closed, so callers should run it in its own goroutine.
+func (l *LinuxPTPConfUpdate) WatchForReload(profilePath string, stopCh <-chan struct{}) {
+	if profilePath == "" {
+		profilePath = DEFAULT_NODE_PROFILE_PATH
+	}
+	l.profileSourcePath = profilePath
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case <-sigCh:
+			glog.Infof("SIGHUP received, reloading node profiles from %s", l.profileSourcePath)
+			if err := l.ReloadConfig(); err != nil {
+				glog.Errorf("failed to reload node profiles on SIGHUP: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// ReloadConfig re-reads l.profileSourcePath and, if its contents differ
+// from the profile currently applied, pushes it through UpdateCh the same
+// way UpdateConfig does. The profile applied before the reload is kept so
+// RollbackConfig can restore it if a downstream consumer (e.g.
+// populatePtp4lConf) rejects the new profile.
+func (l *LinuxPTPConfUpdate) ReloadConfig() error {
+	if l.profileSourcePath == "" {
+		return fmt.Errorf("no profile source configured, call WatchForReload first")
+	}
+
+	nodeProfilesJson, err := os.ReadFile(l.profileSourcePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", l.profileSourcePath, err)
+	}
+
+	l.mu.Lock()
+	if string(l.appliedNodeProfileJson) == string(nodeProfilesJson) {
+		l.mu.Unlock()
+		glog.Infof("reload of %s: no change, skipping", l.profileSourcePath)
+		return nil
+	}
+	previousProfiles := l.NodeProfiles
+	previousJson := l.appliedNodeProfileJson
+	l.mu.Unlock()
+
+	if err := l.UpdateConfig(nodeProfilesJson); err != nil {
+		return fmt.Errorf("reload of %s rejected: %v", l.profileSourcePath, err)
+	}
+
+	l.mu.Lock()
+	newProfiles := l.NodeProfiles
+	l.lastGoodNodeProfileJson = previousJson
+	l.mu.Unlock()
+
+	logProfileChanges(previousProfiles, newProfiles)
+	return nil
+}
+
+// RollbackConfig restores the node profiles applied before the most
+// recent ReloadConfig call. Callers invoke this when applying the reloaded
+// profile fails further down the pipeline (for example populatePtp4lConf
+// returning an error), so ptp4l/phc2sys/ts2phc keep running with the
+// last-known-good config instead of a broken one.
+func (l *LinuxPTPConfUpdate) RollbackConfig() error {
+	l.mu.Lock()
+	lastGood := l.lastGoodNodeProfileJson
+	l.mu.Unlock()
+
+	if lastGood == nil {
+		return fmt.Errorf("no previous node profile available to roll back to")
+	}
+	glog.Warningf("rolling back node profiles to last-known-good config after failed reload")
+	return l.UpdateConfig(lastGood)
+}
+
+// logProfileChanges logs, per profile name, which interfaces were added,
+// removed or changed between an old and a newly reloaded set of profiles.
+func logProfileChanges(oldProfiles, newProfiles []ptpv1.PtpProfile) {
+	for _, change := range diffProfiles(oldProfiles, newProfiles) {
+		glog.Infof("profile reload: %s", change)
+	}
+}
+
+// diffProfiles compares an old and a newly reloaded set of profiles and
+// returns a human-readable description of each profile that was added,
+// removed, or had its interface or ptp4l config change. It's the pure
+// half of logProfileChanges so the comparison logic can be unit tested
+// without capturing glog output.
+func diffProfiles(oldProfiles, newProfiles []ptpv1.PtpProfile) []string {
+	var changes []string
+
+	oldByName := make(map[string]ptpv1.PtpProfile, len(oldProfiles))
+	for _, p := range oldProfiles {
+		if p.Name != nil {
+			oldByName[*p.Name] = p
+		}
+	}
+
+	for _, newProfile := range newProfiles {
+		if newProfile.Name == nil {
+			continue
+		}
+		name := *newProfile.Name
+		oldProfile, existed := oldByName[name]
+		delete(oldByName, name)
+
+		newIface := ""
+		if newProfile.Interface != nil {
+			newIface = *newProfile.Interface
+		}
+
+		if !existed {
+			changes = append(changes, fmt.Sprintf("%s added (interface=%s)", name, newIface))
+			continue
+		}
+
+		oldIface := ""
+		if oldProfile.Interface != nil {
+			oldIface = *oldProfile.Interface
+		}
+		if oldIface != newIface {
+			changes = append(changes, fmt.Sprintf("%s interface changed %q -> %q", name, oldIface, newIface))
+			continue
+		}
+
+		if (oldProfile.Ptp4lConf == nil) != (newProfile.Ptp4lConf == nil) ||
+			(oldProfile.Ptp4lConf != nil && newProfile.Ptp4lConf != nil && *oldProfile.Ptp4lConf != *newProfile.Ptp4lConf) {
+			changes = append(changes, fmt.Sprintf("%s ptp4l config changed", name))
+		}
+	}
+
+	for name := range oldByName {
+		changes = append(changes, fmt.Sprintf("%s removed", name))
+	}
+
+	return changes
+}
+
 // Try to load the multiple policy config
 func tryToLoadConfig(nodeProfilesJson []byte) ([]ptpv1.PtpProfile, bool) {
 	ptpConfig := []ptpv1.PtpProfile{}
@@ -175,6 +350,19 @@ func (output *ptp4lConf) populatePtp4lConf(config *string) error {
 		// Single slave Interface defined
 		output.clock_type = event.OC
 	}
+
+	for _, section := range output.sections {
+		if section.sectionName != "[nmea]" {
+			continue
+		}
+		if serialPort, ok := section.options["ts2phc.nmea_serialport"]; ok {
+			output.gnss_serial_port = strings.TrimSpace(serialPort)
+		}
+		if master, ok := section.options["ts2phc.master"]; ok {
+			output.gnss_source = getSource(master)
+		}
+	}
+
 	return nil
 }
 
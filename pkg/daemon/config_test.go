@@ -0,0 +1,185 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	ptpv1 "github.com/k8snetworkplumbingwg/ptp-operator/api/v1"
+)
+
+func strPtr(s string) *string { return &s }
+
+func profile(name, iface, ptp4lConf string) ptpv1.PtpProfile {
+	p := ptpv1.PtpProfile{Name: strPtr(name), Interface: strPtr(iface)}
+	if ptp4lConf != "" {
+		p.Ptp4lConf = strPtr(ptp4lConf)
+	}
+	return p
+}
+
+func TestDiffProfilesAdded(t *testing.T) {
+	changes := diffProfiles(nil, []ptpv1.PtpProfile{profile("grandmaster", "eth0", "")})
+	if len(changes) != 1 || changes[0] != "grandmaster added (interface=eth0)" {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestDiffProfilesRemoved(t *testing.T) {
+	changes := diffProfiles([]ptpv1.PtpProfile{profile("grandmaster", "eth0", "")}, nil)
+	if len(changes) != 1 || changes[0] != "grandmaster removed" {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestDiffProfilesInterfaceChanged(t *testing.T) {
+	oldProfiles := []ptpv1.PtpProfile{profile("grandmaster", "eth0", "")}
+	newProfiles := []ptpv1.PtpProfile{profile("grandmaster", "eth1", "")}
+	changes := diffProfiles(oldProfiles, newProfiles)
+	if len(changes) != 1 || changes[0] != `grandmaster interface changed "eth0" -> "eth1"` {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestDiffProfilesPtp4lConfChanged(t *testing.T) {
+	old := []ptpv1.PtpProfile{profile("grandmaster", "eth0", "[global]\n")}
+	new := []ptpv1.PtpProfile{profile("grandmaster", "eth0", "[global]\nmasterOnly 1")}
+	changes := diffProfiles(old, new)
+	if len(changes) != 1 || changes[0] != "grandmaster ptp4l config changed" {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+}
+
+func TestDiffProfilesUnchanged(t *testing.T) {
+	p := []ptpv1.PtpProfile{profile("grandmaster", "eth0", "[global]\n")}
+	if changes := diffProfiles(p, p); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+}
+
+func TestDiffProfilesMultiple(t *testing.T) {
+	old := []ptpv1.PtpProfile{profile("a", "eth0", ""), profile("b", "eth1", "")}
+	new := []ptpv1.PtpProfile{profile("b", "eth2", ""), profile("c", "eth3", "")}
+	changes := diffProfiles(old, new)
+	sort.Strings(changes)
+	want := []string{
+		"a removed",
+		`b interface changed "eth1" -> "eth2"`,
+		"c added (interface=eth3)",
+	}
+	sort.Strings(want)
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("unexpected changes: %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestReloadConfigAppliesChangedProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node-profile.json")
+
+	initial := `[{"name":"grandmaster","interface":"eth0"}]`
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write initial profile: %v", err)
+	}
+
+	l := &LinuxPTPConfUpdate{UpdateCh: make(chan bool, 1)}
+	if err := l.UpdateConfig([]byte(initial)); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+	l.profileSourcePath = path
+
+	updated := `[{"name":"grandmaster","interface":"eth1"}]`
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("failed to write updated profile: %v", err)
+	}
+
+	if err := l.ReloadConfig(); err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+
+	if len(l.NodeProfiles) != 1 || *l.NodeProfiles[0].Interface != "eth1" {
+		t.Fatalf("expected reloaded profile to apply, got %+v", l.NodeProfiles)
+	}
+	if string(l.lastGoodNodeProfileJson) != initial {
+		t.Fatalf("expected lastGoodNodeProfileJson to hold the pre-reload profile, got %s", l.lastGoodNodeProfileJson)
+	}
+}
+
+func TestReloadConfigNoopWhenUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node-profile.json")
+
+	initial := `[{"name":"grandmaster","interface":"eth0"}]`
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write initial profile: %v", err)
+	}
+
+	l := &LinuxPTPConfUpdate{UpdateCh: make(chan bool, 1)}
+	if err := l.UpdateConfig([]byte(initial)); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+	l.profileSourcePath = path
+
+	if err := l.ReloadConfig(); err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	select {
+	case <-l.UpdateCh:
+		t.Fatalf("expected no UpdateCh signal for an unchanged reload")
+	default:
+	}
+	if l.lastGoodNodeProfileJson != nil {
+		t.Fatalf("expected no rollback point to be recorded for a no-op reload")
+	}
+}
+
+func TestRollbackConfigRestoresPreviousProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node-profile.json")
+
+	initial := `[{"name":"grandmaster","interface":"eth0"}]`
+	if err := os.WriteFile(path, []byte(initial), 0o644); err != nil {
+		t.Fatalf("failed to write initial profile: %v", err)
+	}
+
+	l := &LinuxPTPConfUpdate{UpdateCh: make(chan bool, 1)}
+	if err := l.UpdateConfig([]byte(initial)); err != nil {
+		t.Fatalf("UpdateConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+	l.profileSourcePath = path
+
+	updated := `[{"name":"grandmaster","interface":"eth1"}]`
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("failed to write updated profile: %v", err)
+	}
+	if err := l.ReloadConfig(); err != nil {
+		t.Fatalf("ReloadConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+
+	if err := l.RollbackConfig(); err != nil {
+		t.Fatalf("RollbackConfig failed: %v", err)
+	}
+	<-l.UpdateCh
+
+	if len(l.NodeProfiles) != 1 || *l.NodeProfiles[0].Interface != "eth0" {
+		t.Fatalf("expected rollback to restore the pre-reload profile, got %+v", l.NodeProfiles)
+	}
+}
+
+func TestRollbackConfigErrorsWithoutPriorReload(t *testing.T) {
+	l := &LinuxPTPConfUpdate{UpdateCh: make(chan bool, 1)}
+	if err := l.RollbackConfig(); err == nil {
+		t.Fatalf("expected RollbackConfig to fail with no prior reload")
+	}
+}
@@ -0,0 +1,308 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/event"
+)
+
+// gnssFixStaleAfter is how long a profile's GNSS source can go without a
+// reported fix before HealthMonitor considers it unhealthy.
+const gnssFixStaleAfter = 60 * time.Second
+
+// ProcessStatus reports whether a single PTP process is running for a
+// profile.
+type ProcessStatus struct {
+	Name  string `json:"name"`
+	Alive bool   `json:"alive"`
+}
+
+// ProfileHealth is the aggregated health of one PtpProfile, derived from
+// its parsed ptp4lConf rather than process liveness alone so a boundary
+// or ordinary clock with every slave port FAULTY is reported unready even
+// though ptp4l/phc2sys/ts2phc/synce4l are all running.
+type ProfileHealth struct {
+	Profile    string            `json:"profile"`
+	ClockType  event.ClockType   `json:"clockType"`
+	Processes  []ProcessStatus   `json:"processes"`
+	GNSSOk     *bool             `json:"gnssOk,omitempty"`
+	SyncEState map[string]string `json:"synceState,omitempty"`
+	Ready      bool              `json:"ready"`
+	Reasons    []string          `json:"reasons,omitempty"`
+}
+
+// HealthStatus is the JSON body served at /status.
+type HealthStatus struct {
+	Profiles []ProfileHealth `json:"profiles"`
+	Ready    bool            `json:"ready"`
+}
+
+// profileState is the health-relevant state HealthMonitor tracks for one
+// profile between Status() calls.
+type profileState struct {
+	conf       *ptp4lConf
+	gnssFixAt  time.Time
+	portStates map[string]string // interface -> last reported port state
+	synceState map[string]string // synce device name -> LastClockState
+	processes  map[string]bool   // process name -> alive, as last reported for this profile
+}
+
+// HealthMonitor aggregates PTP, GNSS and SyncE reachability across every
+// configured profile for Kubernetes liveness/readiness probing. State is
+// pushed in by the daemon's event handling and process supervisor as it
+// parses ptp4l/gnss/synce4l output and manages per-profile process
+// instances; HealthMonitor only aggregates it into a single
+// probe-friendly view.
+type HealthMonitor struct {
+	mu       sync.RWMutex
+	profiles map[string]*profileState
+}
+
+// NewHealthMonitor returns an empty HealthMonitor.
+func NewHealthMonitor() *HealthMonitor {
+	return &HealthMonitor{
+		profiles: make(map[string]*profileState),
+	}
+}
+
+func (h *HealthMonitor) stateFor(profile string) *profileState {
+	st, ok := h.profiles[profile]
+	if !ok {
+		st = &profileState{
+			portStates: map[string]string{},
+			synceState: map[string]string{},
+			processes:  map[string]bool{},
+		}
+		h.profiles[profile] = st
+	}
+	return st
+}
+
+// SetProfile records the parsed ptp4lConf for profile, replacing whatever
+// was previously tracked for it.
+func (h *HealthMonitor) SetProfile(profile string, conf *ptp4lConf) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateFor(profile).conf = conf
+}
+
+// SetPortState records the last reported ptp4l port state (e.g. FAULTY,
+// SLAVE, MASTER) for an interface in a profile.
+func (h *HealthMonitor) SetPortState(profile, iface, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateFor(profile).portStates[iface] = state
+}
+
+// SetGNSSFix records that profile's GNSS source produced a fix at t.
+func (h *HealthMonitor) SetGNSSFix(profile string, t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateFor(profile).gnssFixAt = t
+}
+
+// SetSyncEState records the last SyncE clock state for a device within a
+// profile, as surfaced by synce.Relations.LastClockState.
+func (h *HealthMonitor) SetSyncEState(profile, device, state string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateFor(profile).synceState[device] = state
+}
+
+// SetProcessAlive records whether a named process (ptp4l, phc2sys, ts2phc,
+// synce4l) is alive for a specific profile. Process names alone don't
+// distinguish instances running for different profiles on the same node,
+// so the daemon's process supervisor must report per-profile liveness
+// rather than HealthMonitor inferring it from the host's process table.
+func (h *HealthMonitor) SetProcessAlive(profile, name string, alive bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateFor(profile).processes[name] = alive
+}
+
+// Status computes the current HealthStatus from every tracked profile.
+func (h *HealthMonitor) Status() HealthStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := HealthStatus{Ready: true}
+	for name, st := range h.profiles {
+		ph := h.profileHealth(name, st)
+		status.Profiles = append(status.Profiles, ph)
+		if !ph.Ready {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+func (h *HealthMonitor) profileHealth(name string, st *profileState) ProfileHealth {
+	ph := ProfileHealth{Profile: name, Ready: true}
+	if st.conf != nil {
+		ph.ClockType = st.conf.clock_type
+	}
+
+	for _, proc := range h.processesFor(st) {
+		alive, reported := st.processes[proc]
+		ph.Processes = append(ph.Processes, ProcessStatus{Name: proc, Alive: alive})
+		switch {
+		case !reported:
+			ph.Ready = false
+			ph.Reasons = append(ph.Reasons, fmt.Sprintf("%s: no status reported for this profile", proc))
+		case !alive:
+			ph.Ready = false
+			ph.Reasons = append(ph.Reasons, fmt.Sprintf("%s: process not running", proc))
+		}
+	}
+
+	if st.conf != nil && st.conf.gnss_serial_port != "" && st.conf.gnss_source == event.GNSS {
+		ok := h.gnssHealthy(st)
+		ph.GNSSOk = &ok
+		if !ok {
+			ph.Ready = false
+			age := time.Since(st.gnssFixAt).Round(time.Second)
+			ph.Reasons = append(ph.Reasons, fmt.Sprintf("gnss: no fix for %s", age))
+		}
+	}
+
+	if st.conf != nil {
+		relations := st.conf.extractSynceRelations()
+		for _, dev := range relations.Devices {
+			state := st.synceState[dev.Name]
+			if ph.SyncEState == nil {
+				ph.SyncEState = map[string]string{}
+			}
+			ph.SyncEState[dev.Name] = state
+		}
+	}
+
+	if reason, unready := h.faultyPortsReason(st); unready {
+		ph.Ready = false
+		ph.Reasons = append(ph.Reasons, reason)
+	}
+
+	return ph
+}
+
+// processesFor returns the process names expected to be running for a
+// profile, based on its parsed config.
+func (h *HealthMonitor) processesFor(st *profileState) []string {
+	procs := []string{"ptp4l", "phc2sys"}
+	if st.conf == nil {
+		return procs
+	}
+	if st.conf.gnss_serial_port != "" {
+		procs = append(procs, "ts2phc")
+	}
+	if len(st.conf.extractSynceRelations().Devices) > 0 {
+		procs = append(procs, "synce4l")
+	}
+	return procs
+}
+
+func (h *HealthMonitor) gnssHealthy(st *profileState) bool {
+	if _, err := os.Stat(st.conf.gnss_serial_port); err != nil {
+		return false
+	}
+	if st.gnssFixAt.IsZero() {
+		return false
+	}
+	return time.Since(st.gnssFixAt) <= gnssFixStaleAfter
+}
+
+// faultyPortsReason reports a clock as unready when every slave-facing port
+// tracked for it is FAULTY, mirroring what a real failover would see even
+// though ptp4l/phc2sys/ts2phc are all still running. This applies to both
+// boundary and ordinary clocks (a single FAULTY slave port is just as
+// unready as an OC's one-port case); a grandmaster, which has no slave
+// ports to begin with, is excluded, and so is any port st.conf explicitly
+// marks masterOnly/serverOnly (a boundary clock's healthy uplink port
+// shouldn't mask its slave ports all going FAULTY).
+func (h *HealthMonitor) faultyPortsReason(st *profileState) (string, bool) {
+	if st.conf == nil || st.conf.clock_type == event.GM {
+		return "", false
+	}
+	if len(st.portStates) == 0 {
+		return "", false
+	}
+
+	masterPorts := masterOnlyPorts(st.conf)
+
+	tracked := 0
+	faulty := 0
+	for iface, state := range st.portStates {
+		if masterPorts[iface] {
+			continue
+		}
+		tracked++
+		if state == "FAULTY" {
+			faulty++
+		}
+	}
+	if tracked == 0 {
+		return "", false
+	}
+	if faulty == tracked {
+		return fmt.Sprintf("ptp4l: all %d slave port(s) FAULTY", faulty), true
+	}
+	return "", false
+}
+
+// masterOnlyPorts returns the set of interface names st.conf explicitly
+// marks masterOnly/serverOnly, so faultyPortsReason can exclude a boundary
+// clock's master-facing uplink port from the all-slave-ports-FAULTY check
+// instead of treating every tracked port as a slave port.
+func masterOnlyPorts(conf *ptp4lConf) map[string]bool {
+	ports := map[string]bool{}
+	for _, section := range conf.sections {
+		if isFlagSet(section, "masterOnly") || isFlagSet(section, "serverOnly") {
+			ports[strings.Trim(section.sectionName, "[]")] = true
+		}
+	}
+	return ports
+}
+
+// HealthzHandler serves a minimal liveness probe: 200 as long as the
+// monitor itself is responsive.
+func (h *HealthMonitor) HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// ReadyzHandler serves a readiness probe that fails when any tracked
+// profile is unhealthy.
+func (h *HealthMonitor) ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	if h.Status().Ready {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// StatusHandler serves the full JSON HealthStatus for `oc debug`-style CLI
+// consumers.
+func (h *HealthMonitor) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	status := h.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		glog.Errorf("failed to encode health status: %v", err)
+	}
+}
+
+// RegisterHandlers wires HealthMonitor's handlers onto mux at the
+// conventional /healthz, /readyz and /status paths.
+func (h *HealthMonitor) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", h.HealthzHandler)
+	mux.HandleFunc("/readyz", h.ReadyzHandler)
+	mux.HandleFunc("/status", h.StatusHandler)
+}
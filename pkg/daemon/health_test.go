@@ -0,0 +1,169 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/event"
+)
+
+func TestFaultyPortsReasonOrdinaryClockAllFaulty(t *testing.T) {
+	h := NewHealthMonitor()
+	h.SetProfile("oc", &ptp4lConf{clock_type: event.OC})
+	h.SetPortState("oc", "eth0", "FAULTY")
+
+	st := h.profiles["oc"]
+	reason, unready := h.faultyPortsReason(st)
+	if !unready {
+		t.Fatalf("expected an ordinary clock with its only port FAULTY to be unready")
+	}
+	if reason == "" {
+		t.Fatalf("expected a non-empty reason")
+	}
+}
+
+func TestFaultyPortsReasonBoundaryClockPartiallyFaulty(t *testing.T) {
+	h := NewHealthMonitor()
+	h.SetProfile("bc", &ptp4lConf{clock_type: event.BC})
+	h.SetPortState("bc", "eth0", "FAULTY")
+	h.SetPortState("bc", "eth1", "SLAVE")
+
+	st := h.profiles["bc"]
+	if _, unready := h.faultyPortsReason(st); unready {
+		t.Fatalf("expected a boundary clock with one healthy port to be ready")
+	}
+}
+
+func TestFaultyPortsReasonBoundaryClockMasterPortExcluded(t *testing.T) {
+	h := NewHealthMonitor()
+	confText := "[global]\n[eth0]\nmasterOnly 1\n[eth1]\nmasterOnly 0\n[eth2]\nmasterOnly 0\n"
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&confText); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+	h.SetProfile("bc", conf)
+	h.SetPortState("bc", "eth0", "MASTER")
+	h.SetPortState("bc", "eth1", "FAULTY")
+	h.SetPortState("bc", "eth2", "FAULTY")
+
+	st := h.profiles["bc"]
+	reason, unready := h.faultyPortsReason(st)
+	if !unready {
+		t.Fatalf("expected a boundary clock with all slave ports FAULTY to be unready even with a healthy master port, got reason %q", reason)
+	}
+}
+
+func TestFaultyPortsReasonGrandmasterExcluded(t *testing.T) {
+	h := NewHealthMonitor()
+	h.SetProfile("gm", &ptp4lConf{clock_type: event.GM})
+	h.SetPortState("gm", "eth0", "FAULTY")
+
+	st := h.profiles["gm"]
+	if _, unready := h.faultyPortsReason(st); unready {
+		t.Fatalf("expected a grandmaster (no slave ports expected) to be excluded from the faulty-port check")
+	}
+}
+
+func TestProfileHealthProcessNotReported(t *testing.T) {
+	h := NewHealthMonitor()
+	h.SetProfile("oc", &ptp4lConf{clock_type: event.OC})
+
+	status := h.Status()
+	if status.Ready {
+		t.Fatalf("expected an unready status when no process liveness was ever reported")
+	}
+}
+
+func TestProfileHealthProcessReportedPerProfile(t *testing.T) {
+	h := NewHealthMonitor()
+	h.SetProfile("a", &ptp4lConf{clock_type: event.GM})
+	h.SetProfile("b", &ptp4lConf{clock_type: event.GM})
+	h.SetProcessAlive("a", "ptp4l", true)
+	h.SetProcessAlive("a", "phc2sys", true)
+	h.SetProcessAlive("b", "ptp4l", false)
+	h.SetProcessAlive("b", "phc2sys", true)
+
+	status := h.Status()
+	if status.Ready {
+		t.Fatalf("expected profile b's dead ptp4l to make the overall status unready")
+	}
+
+	var bHealth *ProfileHealth
+	for i := range status.Profiles {
+		if status.Profiles[i].Profile == "b" {
+			bHealth = &status.Profiles[i]
+		}
+	}
+	if bHealth == nil || bHealth.Ready {
+		t.Fatalf("expected profile b to be reported unready independently of profile a")
+	}
+}
+
+func TestProfileHealthPPSSourceNotPenalizedForMissingGNSSFix(t *testing.T) {
+	h := NewHealthMonitor()
+	confText := "[global]\n[nmea]\nts2phc.nmea_serialport /dev/null\nts2phc.master 0\n[eth0]\nmasterOnly 0\n"
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&confText); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+	h.SetProfile("pps", conf)
+	h.SetProcessAlive("pps", "ptp4l", true)
+	h.SetProcessAlive("pps", "phc2sys", true)
+	h.SetProcessAlive("pps", "ts2phc", true)
+	h.SetPortState("pps", "eth0", "SLAVE")
+
+	status := h.Status()
+	var ph *ProfileHealth
+	for i := range status.Profiles {
+		if status.Profiles[i].Profile == "pps" {
+			ph = &status.Profiles[i]
+		}
+	}
+	if ph == nil {
+		t.Fatalf("expected profile pps to be reported")
+	}
+	if ph.GNSSOk != nil {
+		t.Fatalf("expected no GNSS health check for a PPS-sourced profile, got %+v", ph.GNSSOk)
+	}
+	if !ph.Ready {
+		t.Fatalf("expected a PPS-sourced profile with no GNSS fix ever reported to still be ready, got reasons %+v", ph.Reasons)
+	}
+}
+
+func TestProfileHealthGNSSSourceRequiresFix(t *testing.T) {
+	h := NewHealthMonitor()
+	confText := "[global]\n[nmea]\nts2phc.nmea_serialport /dev/null\nts2phc.master 1\n[eth0]\nmasterOnly 0\n"
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&confText); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+	h.SetProfile("gnss", conf)
+	h.SetProcessAlive("gnss", "ptp4l", true)
+	h.SetProcessAlive("gnss", "phc2sys", true)
+	h.SetProcessAlive("gnss", "ts2phc", true)
+	h.SetPortState("gnss", "eth0", "SLAVE")
+
+	status := h.Status()
+	if status.Ready {
+		t.Fatalf("expected a GNSS-sourced profile with no fix ever reported to be unready")
+	}
+}
+
+func TestGNSSHealthyRequiresRecentFix(t *testing.T) {
+	h := NewHealthMonitor()
+	st := &profileState{conf: &ptp4lConf{gnss_serial_port: "/dev/null"}}
+
+	if h.gnssHealthy(st) {
+		t.Fatalf("expected gnssHealthy to be false before any fix was reported")
+	}
+
+	st.gnssFixAt = time.Now().Add(-2 * gnssFixStaleAfter)
+	if h.gnssHealthy(st) {
+		t.Fatalf("expected gnssHealthy to be false once the last fix is stale")
+	}
+
+	st.gnssFixAt = time.Now()
+	if !h.gnssHealthy(st) {
+		t.Fatalf("expected gnssHealthy to be true right after a fresh fix")
+	}
+}
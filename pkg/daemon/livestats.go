@@ -0,0 +1,345 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+
+	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/event"
+
+	ptpv1 "github.com/k8snetworkplumbingwg/ptp-operator/api/v1"
+)
+
+// subscriberBuffer is the depth of each stream subscriber's channel. A
+// subscriber that falls behind has its oldest queued update dropped rather
+// than blocking the stats pipeline (see Stats.publish).
+const subscriberBuffer = 8
+
+// InterfaceStats is a point-in-time view of a single interface's PTP state,
+// keyed by interface name in Stats. It mirrors the fields tracked per port
+// by renderPtp4lConf/extractSynceRelations but flattened for consumption
+// by an operator-facing CLI or dashboard.
+type InterfaceStats struct {
+	Interface           string            `json:"interface"`
+	OffsetFromMasterNs  float64           `json:"offsetFromMasterNs"`
+	MeanPathDelayNs     float64           `json:"meanPathDelayNs"`
+	FrequencyAdjustment float64           `json:"frequencyAdjustment"`
+	ServoState          string            `json:"servoState"`
+	PortState           string            `json:"portState"`
+	Source              event.EventSource `json:"source"`
+	SyncEQualityLevel   string            `json:"synceQualityLevel,omitempty"`
+	UpdatedAt           time.Time         `json:"updatedAt"`
+}
+
+// Stats is a thread-safe registry of the latest InterfaceStats per
+// interface, with support for streaming subscribers modeled on `docker
+// stats --stream`. Updates arrive from the event bus as the daemon parses
+// ptp4l/phc2sys/ts2phc/synce4l output; Stats only aggregates and fans them
+// out.
+type Stats struct {
+	mu          sync.RWMutex
+	byIface     map[string]*InterfaceStats
+	subscribers map[chan *InterfaceStats]struct{}
+}
+
+// NewStats returns an empty Stats registry.
+func NewStats() *Stats {
+	return &Stats{
+		byIface:     make(map[string]*InterfaceStats),
+		subscribers: make(map[chan *InterfaceStats]struct{}),
+	}
+}
+
+// Update records the latest stats for an interface and fans it out to any
+// active stream subscribers.
+func (s *Stats) Update(stat *InterfaceStats) {
+	stat.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	s.byIface[stat.Interface] = stat
+	s.mu.Unlock()
+
+	s.publish(stat)
+}
+
+// Snapshot returns the latest stats for every known interface.
+func (s *Stats) Snapshot() []*InterfaceStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]*InterfaceStats, 0, len(s.byIface))
+	for _, stat := range s.byIface {
+		out = append(out, stat)
+	}
+	return out
+}
+
+// subscribe registers a new stream subscriber and returns its channel
+// along with a function to unregister it.
+func (s *Stats) subscribe() (chan *InterfaceStats, func()) {
+	ch := make(chan *InterfaceStats, subscriberBuffer)
+
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// publish fans stat out to every subscriber without blocking on a slow
+// reader: a full channel has its oldest entry dropped to make room for the
+// new one.
+func (s *Stats) publish(stat *InterfaceStats) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- stat:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			select {
+			case ch <- stat:
+			default:
+			}
+		}
+	}
+}
+
+// StatsCollector keeps a Stats registry in sync with the daemon's parsed
+// ptp4l config. It derives a baseline InterfaceStats entry per interface
+// from renderPtp4lConf's ifaces and extractSynceRelations' SyncE devices
+// (so every configured interface shows up in a snapshot/stream even
+// before the first live sample arrives), and lets the daemon's own
+// metrics parsing update the live numeric/state fields as samples come
+// in.
+type StatsCollector struct {
+	stats *Stats
+
+	// lastSeenConf tracks the Ptp4lConf text last seeded per profile name,
+	// so Watch only re-seeds a profile whose config actually changed
+	// instead of re-seeding every profile on every UpdateCh fire.
+	lastSeenConf map[string]string
+}
+
+// NewStatsCollector returns a StatsCollector that feeds stats.
+func NewStatsCollector(stats *Stats) *StatsCollector {
+	return &StatsCollector{stats: stats, lastSeenConf: map[string]string{}}
+}
+
+// SeedProfile projects conf's interfaces and SyncE relations into baseline
+// InterfaceStats entries for every port renderPtp4lConf knows about,
+// carrying over each port's event source and the SyncE quality level held
+// in LastQLState of the relations extracted from conf. Those relations are
+// built fresh from the config text, so their LastQLState is empty and the
+// seeded SyncEQualityLevel is "". It merges onto whatever entry already exists for an interface
+// (mirroring ReportMetrics's own merge-onto-existing approach) rather than
+// replacing it outright, so re-seeding a profile on reload doesn't flatline
+// the live offset/delay/servo/port-state fields ReportMetrics has already
+// recorded.
+func (c *StatsCollector) SeedProfile(conf *ptp4lConf) {
+	_, ifaces := conf.renderPtp4lConf()
+	relations := conf.extractSynceRelations()
+
+	qlByIface := map[string]string{}
+	for _, dev := range relations.Devices {
+		for _, iface := range dev.Ifaces {
+			if ql, ok := dev.LastQLState[iface]; ok && ql != nil {
+				qlByIface[iface] = fmt.Sprintf("%v", *ql)
+			}
+		}
+	}
+
+	for _, iface := range ifaces {
+		c.stats.SeedIface(iface.Name, iface.Source, qlByIface[iface.Name])
+	}
+}
+
+// seedChangedProfiles re-seeds Stats only for the profiles whose Ptp4lConf
+// text differs from what was last seeded. It's the pure half of Watch so
+// the change-detection logic can be unit tested without driving UpdateCh
+// through a goroutine.
+func (c *StatsCollector) seedChangedProfiles(profiles []ptpv1.PtpProfile) {
+	for _, profile := range profiles {
+		name := ""
+		if profile.Name != nil {
+			name = *profile.Name
+		}
+		confText := ""
+		if profile.Ptp4lConf != nil {
+			confText = *profile.Ptp4lConf
+		}
+
+		if last, ok := c.lastSeenConf[name]; ok && last == confText {
+			continue
+		}
+		c.lastSeenConf[name] = confText
+
+		conf := &ptp4lConf{profile_name: name}
+		if err := conf.populatePtp4lConf(profile.Ptp4lConf); err != nil {
+			glog.Errorf("stats collector: failed to parse ptp4l config for profile %s: %v", name, err)
+			continue
+		}
+		c.SeedProfile(conf)
+	}
+}
+
+// Watch drives seedChangedProfiles off confUpdate.UpdateCh, the signal
+// LinuxPTPConfUpdate already uses to announce a newly applied set of node
+// profiles - the closest thing this daemon has to an event bus for config
+// changes. It blocks until stopCh is closed, so callers should run it in
+// its own goroutine.
+func (c *StatsCollector) Watch(confUpdate *LinuxPTPConfUpdate, stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-confUpdate.UpdateCh:
+			c.seedChangedProfiles(confUpdate.NodeProfiles)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// SeedIface records the baseline Source/SyncEQualityLevel config derives
+// for iface without disturbing whatever live numeric/state fields
+// ReportMetrics has already recorded for it, the same merge-onto-existing
+// approach ReportMetrics itself uses for the fields it owns.
+func (s *Stats) SeedIface(iface string, source event.EventSource, syncEQualityLevel string) {
+	s.mu.RLock()
+	existing, ok := s.byIface[iface]
+	s.mu.RUnlock()
+
+	stat := InterfaceStats{Interface: iface}
+	if ok {
+		stat = *existing
+	}
+
+	stat.Source = source
+	stat.SyncEQualityLevel = syncEQualityLevel
+
+	s.Update(&stat)
+}
+
+// ReportMetrics updates the live numeric/state fields for iface - the
+// values ptp4l/phc2sys/ts2phc report on every sample - without disturbing
+// the Source/SyncEQualityLevel baseline SeedProfile established for it.
+func (s *Stats) ReportMetrics(iface string, offsetFromMasterNs, meanPathDelayNs, frequencyAdjustment float64, servoState, portState string) {
+	s.mu.RLock()
+	existing, ok := s.byIface[iface]
+	s.mu.RUnlock()
+
+	stat := InterfaceStats{Interface: iface}
+	if ok {
+		stat = *existing
+	}
+
+	stat.OffsetFromMasterNs = offsetFromMasterNs
+	stat.MeanPathDelayNs = meanPathDelayNs
+	stat.FrequencyAdjustment = frequencyAdjustment
+	stat.ServoState = servoState
+	stat.PortState = portState
+
+	s.Update(&stat)
+}
+
+// StatsServer exposes a Stats registry over a local Unix socket, giving
+// operators a `docker stats`-like view of a boundary clock without
+// scraping Prometheus. It serves a one-shot JSON snapshot at /snapshot and
+// a line-delimited JSON stream at /stream that emits on every update or at
+// least every pollInterval.
+type StatsServer struct {
+	stats        *Stats
+	pollInterval time.Duration
+}
+
+// NewStatsServer returns a StatsServer backed by stats. pollInterval is
+// the minimum cadence at which /stream emits a full snapshot even if no
+// update was published; it defaults to 1s when zero or negative.
+func NewStatsServer(stats *Stats, pollInterval time.Duration) *StatsServer {
+	if pollInterval <= 0 {
+		pollInterval = time.Second
+	}
+	return &StatsServer{stats: stats, pollInterval: pollInterval}
+}
+
+// ListenAndServe listens on socketPath (removing any stale socket file
+// left behind by a previous run) and serves until the listener is closed.
+func (s *StatsServer) ListenAndServe(socketPath string) error {
+	_ = os.Remove(socketPath)
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/snapshot", s.handleSnapshot)
+	mux.HandleFunc("/stream", s.handleStream)
+
+	glog.Infof("ptp stats server listening on %s", socketPath)
+	return http.Serve(listener, mux)
+}
+
+func (s *StatsServer) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.stats.Snapshot()); err != nil {
+		glog.Errorf("failed to encode stats snapshot: %v", err)
+	}
+}
+
+func (s *StatsServer) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch, unsubscribe := s.stats.subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	ctx := r.Context()
+
+	emit := func(stat *InterfaceStats) bool {
+		if err := enc.Encode(stat); err != nil {
+			return false
+		}
+		flusher.Flush()
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case stat := <-ch:
+			if !emit(stat) {
+				return
+			}
+		case <-ticker.C:
+			for _, stat := range s.stats.Snapshot() {
+				if !emit(stat) {
+					return
+				}
+			}
+		}
+	}
+}
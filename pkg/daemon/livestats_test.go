@@ -0,0 +1,163 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/event"
+
+	ptpv1 "github.com/k8snetworkplumbingwg/ptp-operator/api/v1"
+)
+
+func TestStatsSnapshotReflectsLatestUpdate(t *testing.T) {
+	s := NewStats()
+	s.Update(&InterfaceStats{Interface: "eth0", OffsetFromMasterNs: 10})
+	s.Update(&InterfaceStats{Interface: "eth0", OffsetFromMasterNs: 20})
+
+	snap := s.Snapshot()
+	if len(snap) != 1 || snap[0].OffsetFromMasterNs != 20 {
+		t.Fatalf("expected one entry with the latest offset, got %+v", snap)
+	}
+}
+
+func TestStatsPublishDropsOldestForSlowSubscriber(t *testing.T) {
+	s := NewStats()
+	ch, unsubscribe := s.subscribe()
+	defer unsubscribe()
+
+	total := subscriberBuffer + 3
+	for i := 0; i < total; i++ {
+		s.Update(&InterfaceStats{Interface: "eth0", OffsetFromMasterNs: float64(i)})
+	}
+
+	if got := len(ch); got != subscriberBuffer {
+		t.Fatalf("expected the subscriber channel to be full at %d, got %d", subscriberBuffer, got)
+	}
+
+	var last float64 = -1
+	for len(ch) > 0 {
+		stat := <-ch
+		if stat.OffsetFromMasterNs <= last {
+			t.Fatalf("expected drop-oldest to preserve arrival order, got %v after %v", stat.OffsetFromMasterNs, last)
+		}
+		last = stat.OffsetFromMasterNs
+	}
+	if last != float64(total-1) {
+		t.Fatalf("expected the most recent update to survive drop-oldest, got %v", last)
+	}
+}
+
+func TestStatsPublishDoesNotBlockWithoutSubscribers(t *testing.T) {
+	s := NewStats()
+	s.Update(&InterfaceStats{Interface: "eth0"})
+}
+
+func TestReportMetricsPreservesSeededFields(t *testing.T) {
+	s := NewStats()
+	s.Update(&InterfaceStats{Interface: "eth0", Source: event.GNSS, SyncEQualityLevel: "QL-PRC"})
+
+	s.ReportMetrics("eth0", 100, 50, 1.5, "LOCKED", "SLAVE")
+
+	snap := s.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected a single interface entry, got %+v", snap)
+	}
+	got := snap[0]
+	if got.Source != event.GNSS || got.SyncEQualityLevel != "QL-PRC" {
+		t.Fatalf("expected ReportMetrics to preserve the seeded Source/SyncEQualityLevel, got %+v", got)
+	}
+	if got.OffsetFromMasterNs != 100 || got.MeanPathDelayNs != 50 || got.FrequencyAdjustment != 1.5 ||
+		got.ServoState != "LOCKED" || got.PortState != "SLAVE" {
+		t.Fatalf("expected ReportMetrics to update the live fields, got %+v", got)
+	}
+}
+
+func TestSeedProfileProjectsIfacesFromConfig(t *testing.T) {
+	confText := "[global]\n" +
+		"[eth0]\n" +
+		"masterOnly 0\n" +
+		"ts2phc.master 1\n" +
+		"[eth1]\n" +
+		"masterOnly 1\n"
+
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&confText); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+
+	stats := NewStats()
+	NewStatsCollector(stats).SeedProfile(conf)
+
+	byIface := map[string]*InterfaceStats{}
+	for _, stat := range stats.Snapshot() {
+		byIface[stat.Interface] = stat
+	}
+
+	eth0, ok := byIface["eth0"]
+	if !ok {
+		t.Fatalf("expected eth0 to be seeded, got %+v", byIface)
+	}
+	if eth0.Source != event.GNSS {
+		t.Fatalf("expected eth0's source to be GNSS per its ts2phc.master flag, got %v", eth0.Source)
+	}
+
+	if _, ok := byIface["eth1"]; !ok {
+		t.Fatalf("expected eth1 to be seeded, got %+v", byIface)
+	}
+}
+
+func TestSeedProfileMergesOntoExistingStats(t *testing.T) {
+	stats := NewStats()
+	stats.ReportMetrics("eth0", 100, 50, 1.5, "LOCKED", "SLAVE")
+
+	confText := "[global]\n[eth0]\nmasterOnly 0\n"
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&confText); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+	NewStatsCollector(stats).SeedProfile(conf)
+
+	snap := stats.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected a single interface entry, got %+v", snap)
+	}
+	got := snap[0]
+	if got.OffsetFromMasterNs != 100 || got.MeanPathDelayNs != 50 || got.FrequencyAdjustment != 1.5 ||
+		got.ServoState != "LOCKED" || got.PortState != "SLAVE" {
+		t.Fatalf("expected SeedProfile to preserve live fields ReportMetrics already recorded, got %+v", got)
+	}
+}
+
+func TestSeedChangedProfilesSkipsUnchangedProfile(t *testing.T) {
+	stats := NewStats()
+	collector := NewStatsCollector(stats)
+
+	statFor := func(iface string) *InterfaceStats {
+		for _, stat := range stats.Snapshot() {
+			if stat.Interface == iface {
+				return stat
+			}
+		}
+		return nil
+	}
+
+	confText := "[global]\n[eth0]\nmasterOnly 0\nts2phc.master 1\n"
+	collector.seedChangedProfiles([]ptpv1.PtpProfile{profile("a", "eth0", confText)})
+
+	got := statFor("eth0")
+	if got == nil || got.Source != event.GNSS {
+		t.Fatalf("expected eth0 to be seeded with GNSS source, got %+v", got)
+	}
+
+	// Simulate eth0's live state diverging from its config-derived
+	// baseline between reloads.
+	stats.Update(&InterfaceStats{Interface: "eth0", Source: event.PPS})
+
+	// Re-fire with the identical profile: since its Ptp4lConf text hasn't
+	// changed, seedChangedProfiles must not touch eth0 again.
+	collector.seedChangedProfiles([]ptpv1.PtpProfile{profile("a", "eth0", confText)})
+
+	got = statFor("eth0")
+	if got == nil || got.Source != event.PPS {
+		t.Fatalf("expected an unchanged profile to be skipped on reseed, got %+v", got)
+	}
+}
@@ -0,0 +1,301 @@
+package daemon
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+
+	"github.com/k8snetworkplumbingwg/linuxptp-daemon/pkg/synce"
+)
+
+// Severity is the severity of a single Diagnostic.
+type Severity string
+
+const (
+	// SeverityError means the config is structurally broken or would be
+	// silently misinterpreted; a --dry-run caller should reject it.
+	SeverityError Severity = "error"
+	// SeverityWarning flags a config that ptp4l/synce4l would likely
+	// still accept but that probably isn't what the author intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is a single finding from ptp4lConf.Validate, replacing the
+// single wrapped error populatePtp4lConf returns today with enough detail
+// to point an admission webhook or CI check at the offending option.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Section  string   `json:"section"`
+	Key      string   `json:"key,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// synceDeviceAllowedKeys are the only options extractSynceRelations and
+// renderSyncE4lConf understand on a `[<name>]` device section.
+var synceDeviceAllowedKeys = map[string]bool{
+	"network_option": true,
+	"extended_tlv":   true,
+	"clock_id":       true,
+}
+
+// The two ITU-T G.8264 network options and the on/off states for
+// extended_tlv that ptp4l/synce4l accept. synce.SYNCE_NETWORK_OPT_1 and
+// synce.ExtendedTLV_DISABLED are the values extractSynceRelations defaults
+// to; the other side of each pair isn't exported by the synce package, so
+// it's named here.
+const (
+	synceNetworkOpt1         = synce.SYNCE_NETWORK_OPT_1
+	synceNetworkOpt2         = 2
+	synceExtendedTlvDisabled = synce.ExtendedTLV_DISABLED
+	synceExtendedTlvEnabled  = 1
+)
+
+// ptp4lGlobalAndPortAllowedKeys lists the ptp4l.conf/ts2phc options this
+// daemon and upstream linuxptp recognize on [global] and interface port
+// sections. It is not exhaustive - linuxptp gains options across releases
+// - so an unrecognized key here is a warning rather than an error, to
+// avoid a --dry-run/admission-webhook check rejecting an otherwise-valid
+// profile on a key this list hasn't caught up with yet.
+var ptp4lGlobalAndPortAllowedKeys = map[string]bool{
+	"twoStepFlag":               true,
+	"slaveOnly":                 true,
+	"masterOnly":                true,
+	"serverOnly":                true,
+	"clientOnly":                true,
+	"priority1":                 true,
+	"priority2":                 true,
+	"domainNumber":              true,
+	"clockClass":                true,
+	"clockAccuracy":             true,
+	"offsetScaledLogVariance":   true,
+	"free_running":              true,
+	"freq_est_interval":         true,
+	"dscp_event":                true,
+	"dscp_general":              true,
+	"dataset_comparison":        true,
+	"maxStepsRemoved":           true,
+	"logAnnounceInterval":       true,
+	"logSyncInterval":           true,
+	"logMinDelayReqInterval":    true,
+	"logMinPdelayReqInterval":   true,
+	"announceReceiptTimeout":    true,
+	"syncReceiptTimeout":        true,
+	"delayAsymmetry":            true,
+	"fault_reset_interval":      true,
+	"neighborPropDelayThresh":   true,
+	"delay_mechanism":           true,
+	"network_transport":         true,
+	"udp_ttl":                   true,
+	"udp6_scope":                true,
+	"uds_address":               true,
+	"clock_servo":               true,
+	"sanity_freq_limit":         true,
+	"time_source":               true,
+	"logging_level":             true,
+	"path_trace_enabled":        true,
+	"follow_up_info":            true,
+	"hybrid_e2e":                true,
+	"inhibit_multicast_service": true,
+	"net_sync_monitor":          true,
+	"tc_spanning_tree":          true,
+	"tx_timestamp_timeout":      true,
+	"unicast_listen":            true,
+	"unicast_master_table":      true,
+	"unicast_req_duration":      true,
+	"use_syslog":                true,
+	"verbose":                   true,
+	"summary_interval":          true,
+	"kernel_leap":               true,
+	"check_fault_fd":            true,
+	"assume_two_step":           true,
+	"boundary_clock_jbod":       true,
+	"manufacturerIdentity":      true,
+	"productDescription":        true,
+	"revisionData":              true,
+	"userDescription":           true,
+	"ts2phc.master":             true,
+	"ts2phc.nmea_serialport":    true,
+	"ts2phc.pulsewidth":         true,
+}
+
+// Validate runs a set of structural and semantic checks over an already
+// populated ptp4lConf and returns every problem found instead of failing
+// fast on the first one, the way populatePtp4lConf's single error does.
+// It does not mutate conf.
+func (conf *ptp4lConf) Validate() []Diagnostic {
+	diags := []Diagnostic{}
+	seenSections := map[string]bool{}
+
+	for _, section := range conf.sections {
+		if seenSections[section.sectionName] {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Section:  section.sectionName,
+				Message:  "duplicate section",
+			})
+		}
+		seenSections[section.sectionName] = true
+
+		switch {
+		case strings.HasPrefix(section.sectionName, "[<"):
+			diags = append(diags, validateSynceDeviceSection(section)...)
+		case strings.HasPrefix(section.sectionName, "[{"):
+			diags = append(diags, validateExternalSourceSection(section)...)
+		default:
+			diags = append(diags, validateMasterSlaveFlags(section)...)
+		}
+	}
+
+	for _, dev := range conf.extractSynceRelations().Devices {
+		if len(dev.Ifaces) == 0 {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Section:  fmt.Sprintf("[<%s>]", dev.Name),
+				Message:  "synce device has no port sections",
+			})
+		}
+	}
+
+	return diags
+}
+
+// validateMasterSlaveFlags checks a [global] or port section: the
+// masterOnly/slaveOnly/serverOnly/clientOnly combinations that today
+// silently confuse hasSlaveConfigDefined, unparseable ts2phc.master
+// values, and keys outside ptp4lGlobalAndPortAllowedKeys.
+func validateMasterSlaveFlags(section ptp4lConfSection) []Diagnostic {
+	diags := []Diagnostic{}
+
+	for k := range section.options {
+		if !ptp4lGlobalAndPortAllowedKeys[k] {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Section:  section.sectionName,
+				Key:      k,
+				Message:  "unknown key for a global/port section",
+			})
+		}
+	}
+
+	isMaster := isFlagSet(section, "masterOnly") || isFlagSet(section, "serverOnly")
+	isSlave := isFlagSet(section, "slaveOnly") || isFlagSet(section, "clientOnly")
+	if isMaster && isSlave {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityError,
+			Section:  section.sectionName,
+			Key:      "masterOnly/slaveOnly",
+			Message:  "section is configured as both master and slave",
+		})
+	}
+
+	if v, ok := section.options["ts2phc.master"]; ok {
+		if _, err := strconv.ParseBool(strings.TrimSpace(v)); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Section:  section.sectionName,
+				Key:      "ts2phc.master",
+				Message:  fmt.Sprintf("value %q is not a parseable boolean", v),
+			})
+		}
+	}
+
+	return diags
+}
+
+func isFlagSet(section ptp4lConfSection, key string) bool {
+	v, ok := section.options[key]
+	if !ok {
+		return false
+	}
+	return strings.TrimSpace(v) == "1"
+}
+
+// validateSynceDeviceSection checks a `[<name>]` section against the keys
+// extractSynceRelations actually reads, and the network_option/
+// extended_tlv ranges. extractSynceRelations itself passes an out-of-range
+// integer through unchanged and, for a value that isn't an integer, only
+// logs with glog.Errorf and uses 0.
+func validateSynceDeviceSection(section ptp4lConfSection) []Diagnostic {
+	diags := []Diagnostic{}
+
+	for k := range section.options {
+		if !synceDeviceAllowedKeys[k] {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityWarning,
+				Section:  section.sectionName,
+				Key:      k,
+				Message:  "unknown key for a synce device section",
+			})
+		}
+	}
+
+	// network_option selects one of the two ITU-T G.8264 option sets;
+	// extractSynceRelations accepts any other integer as-is.
+	if v, ok := section.options["network_option"]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || (n != synceNetworkOpt1 && n != synceNetworkOpt2) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Section:  section.sectionName,
+				Key:      "network_option",
+				Message:  fmt.Sprintf("value %q is out of range", v),
+			})
+		}
+	}
+
+	if v, ok := section.options["extended_tlv"]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || (n != synceExtendedTlvDisabled && n != synceExtendedTlvEnabled) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Section:  section.sectionName,
+				Key:      "extended_tlv",
+				Message:  fmt.Sprintf("value %q is out of range", v),
+			})
+		}
+	}
+
+	return diags
+}
+
+// validateExternalSourceSection checks a `[{name}]` section. Only the
+// bracketed name itself is meaningful to extractSynceRelations; any option
+// under it is ignored today and is flagged as likely author error.
+func validateExternalSourceSection(section ptp4lConfSection) []Diagnostic {
+	diags := []Diagnostic{}
+	for k := range section.options {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Section:  section.sectionName,
+			Key:      k,
+			Message:  "external source sections do not take options",
+		})
+	}
+	return diags
+}
+
+// RunDryRun validates conf and prints the diagnostics as JSON to stdout,
+// for wiring into a --dry-run daemon flag. It returns true when no
+// error-severity diagnostic was found; callers should exit non-zero and
+// skip writing rendered configs or restarting ptp processes otherwise.
+func RunDryRun(conf *ptp4lConf) bool {
+	diags := conf.Validate()
+
+	ok := true
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			ok = false
+		}
+	}
+
+	out, err := json.MarshalIndent(diags, "", "  ")
+	if err != nil {
+		glog.Errorf("failed to marshal dry-run diagnostics: %v", err)
+		return false
+	}
+	fmt.Println(string(out))
+	return ok
+}
@@ -0,0 +1,141 @@
+package daemon
+
+import "testing"
+
+func parseConf(t *testing.T, text string) *ptp4lConf {
+	t.Helper()
+	conf := &ptp4lConf{}
+	if err := conf.populatePtp4lConf(&text); err != nil {
+		t.Fatalf("populatePtp4lConf failed: %v", err)
+	}
+	return conf
+}
+
+func hasDiagnostic(diags []Diagnostic, severity Severity, section, key string) bool {
+	for _, d := range diags {
+		if d.Severity == severity && d.Section == section && d.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func TestValidateDuplicateSection(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nmasterOnly 1\n[eth0]\nmasterOnly 1\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[eth0]", "") {
+		t.Fatalf("expected a duplicate section diagnostic, got %+v", diags)
+	}
+}
+
+func TestValidateTs2phcMasterUnparseable(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nts2phc.master notabool\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[eth0]", "ts2phc.master") {
+		t.Fatalf("expected a ts2phc.master diagnostic, got %+v", diags)
+	}
+}
+
+func TestValidateTs2phcMasterValid(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nts2phc.master 1\n")
+	diags := conf.Validate()
+	if hasDiagnostic(diags, SeverityError, "[eth0]", "ts2phc.master") {
+		t.Fatalf("did not expect a ts2phc.master diagnostic for a valid boolean, got %+v", diags)
+	}
+}
+
+func TestValidateMasterSlaveConflict(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nmasterOnly 1\nslaveOnly 1\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[eth0]", "masterOnly/slaveOnly") {
+		t.Fatalf("expected a master/slave conflict diagnostic, got %+v", diags)
+	}
+}
+
+func TestValidateUnknownGlobalPortKey(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nmasterOnly 1\nbogus_option 1\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityWarning, "[eth0]", "bogus_option") {
+		t.Fatalf("expected an unknown-key warning for bogus_option, got %+v", diags)
+	}
+}
+
+func TestValidateKnownGlobalPortKeysDoNotWarn(t *testing.T) {
+	conf := parseConf(t, "[global]\npriority1 128\npriority2 128\n[eth0]\nmasterOnly 1\nlogAnnounceInterval 1\n")
+	diags := conf.Validate()
+	for _, d := range diags {
+		if d.Message == "unknown key for a global/port section" {
+			t.Fatalf("did not expect an unknown-key warning for a recognized key, got %+v", d)
+		}
+	}
+}
+
+func TestValidateSynceDeviceNetworkOptionOutOfRange(t *testing.T) {
+	conf := parseConf(t, "[global]\n[<synce1>]\nnetwork_option 99\n[eth0]\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[<synce1>]", "network_option") {
+		t.Fatalf("expected a network_option range diagnostic, got %+v", diags)
+	}
+}
+
+func TestValidateSynceDeviceNetworkOptionInRange(t *testing.T) {
+	conf := parseConf(t, "[global]\n[<synce1>]\nnetwork_option 2\n[eth0]\n")
+	diags := conf.Validate()
+	if hasDiagnostic(diags, SeverityError, "[<synce1>]", "network_option") {
+		t.Fatalf("did not expect a network_option diagnostic for a valid value, got %+v", diags)
+	}
+}
+
+func TestValidateSynceDeviceExtendedTlvOutOfRange(t *testing.T) {
+	conf := parseConf(t, "[global]\n[<synce1>]\nextended_tlv 7\n[eth0]\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[<synce1>]", "extended_tlv") {
+		t.Fatalf("expected an extended_tlv range diagnostic, got %+v", diags)
+	}
+}
+
+func TestValidateSynceDeviceUnknownKey(t *testing.T) {
+	conf := parseConf(t, "[global]\n[<synce1>]\nbogus_key 1\n[eth0]\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityWarning, "[<synce1>]", "bogus_key") {
+		t.Fatalf("expected an unknown-key warning for the synce device section, got %+v", diags)
+	}
+}
+
+func TestValidateSynceDeviceNoPorts(t *testing.T) {
+	conf := parseConf(t, "[global]\n[<synce1>]\nnetwork_option 1\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityError, "[<synce1>]", "") {
+		t.Fatalf("expected a no-ports diagnostic for a synce device with no port sections, got %+v", diags)
+	}
+}
+
+func TestValidateExternalSourceWithOptionsWarns(t *testing.T) {
+	conf := parseConf(t, "[global]\n[{ext}]\nsomekey 1\n[eth0]\n")
+	diags := conf.Validate()
+	if !hasDiagnostic(diags, SeverityWarning, "[{ext}]", "somekey") {
+		t.Fatalf("expected a warning for an option under an external source section, got %+v", diags)
+	}
+}
+
+func TestValidateCleanConfigHasNoDiagnostics(t *testing.T) {
+	conf := parseConf(t, "[global]\npriority1 128\n[eth0]\nmasterOnly 1\n[eth1]\nslaveOnly 1\n")
+	diags := conf.Validate()
+	if len(diags) != 0 {
+		t.Fatalf("expected no diagnostics for a clean config, got %+v", diags)
+	}
+}
+
+func TestRunDryRunReturnsFalseOnError(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nts2phc.master notabool\n")
+	if RunDryRun(conf) {
+		t.Fatalf("expected RunDryRun to return false when an error diagnostic is present")
+	}
+}
+
+func TestRunDryRunReturnsTrueWhenClean(t *testing.T) {
+	conf := parseConf(t, "[global]\n[eth0]\nmasterOnly 1\n")
+	if !RunDryRun(conf) {
+		t.Fatalf("expected RunDryRun to return true for a clean config")
+	}
+}